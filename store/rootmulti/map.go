@@ -4,187 +4,231 @@ import (
 	"bytes"
 	"encoding/binary"
 	"io"
+	"math/bits"
+	"sort"
 
 	"github.com/tendermint/tendermint/crypto/merkle"
 	"github.com/tendermint/tendermint/crypto/tmhash"
-	"github.com/tendermint/tendermint/libs/kv"
 )
 
-// merkleMap defines a merkle-ized tree from a map. Leave values are treated as
-// hash(key) | hash(value). Leaves are sorted before Merkle hashing.
-type merkleMap struct {
-	kvs    kv.Pairs
-	sorted bool
-}
-
-func newMerkleMap() *merkleMap {
-	return &merkleMap{
-		kvs:    nil,
-		sorted: false,
+// MerkleMap is a Merkle-ized map from store name to store root hash, used to
+// compute CommitInfo's commitment hash and to produce per-store inclusion
+// proofs. It replaces the former merkleMap/simpleMap types, which both
+// re-sorted and re-hashed every key on every call: MerkleMap instead keeps a
+// cached binary tree of inner-node hashes and, on Set, recomputes only the
+// path from the changed leaf up to the root.
+type MerkleMap struct {
+	keys   []string          // sorted ascending; a key's position is its leaf index
+	leaves map[string][]byte // key -> hash(value)
+	root   *merkleNode       // cached tree; nil if it needs to be (re)built
+}
+
+// NewMerkleMap returns an empty MerkleMap.
+func NewMerkleMap() *MerkleMap {
+	return &MerkleMap{
+		leaves: make(map[string][]byte),
 	}
 }
 
-// set creates a kv.Pair from the provided key and value. The value is hashed prior
-// to creating a kv.Pair. The created kv.Pair is appended to the merkleMap's slice
-// of kv.Pairs. Whenever called, the merkleMap must be resorted.
-func (sm *merkleMap) set(key string, value []byte) {
-	sm.sorted = false
-
-	// The value is hashed, so you can check for equality with a cached value (say)
-	// and make a determination to fetch or not.
+// Set records the hash of value under key.
+//
+// If key is new, every leaf's index shifts, so the cached tree is dropped
+// and rebuilt from scratch on the next Hash/Proof call. If key already
+// exists, only the O(log n) path from its leaf to the root is recomputed.
+func (m *MerkleMap) Set(key string, value []byte) {
 	vhash := tmhash.Sum(value)
-
-	sm.kvs = append(sm.kvs, kv.Pair{
-		Key:   []byte(key),
-		Value: vhash,
-	})
+	leafBz := kvPair{Key: []byte(key), Value: vhash}.bytes()
+
+	_, exists := m.leaves[key]
+	m.leaves[key] = vhash
+
+	switch {
+	case !exists:
+		m.keys = append(m.keys, key)
+		sort.Strings(m.keys)
+		m.root = nil
+	case m.root != nil:
+		m.root.update(m.indexOf(key), leafBz)
+	}
 }
 
-// hash returns the merkle root of items sorted by key. Note, it is unstable.
-func (sm *merkleMap) hash() []byte {
-	sm.sort()
-	return hashKVPairs(sm.kvs)
+// Hash returns the Merkle root of the map, sorted by key.
+func (m *MerkleMap) Hash() []byte {
+	return m.tree().hash
 }
 
-func (sm *merkleMap) sort() {
-	if sm.sorted {
-		return
-	}
+// Proof returns a merkle.SimpleProof of inclusion for key's current value,
+// along with the raw leaf pre-image it proves. The proof is read from the
+// cached tree, which is first rebuilt in full if a key was inserted since the
+// last Hash/Proof call. merkle.SimpleProof.Verify
+// hashes its leaf argument internally before comparing it against
+// proof.LeafHash, so callers must pass this pre-image, not leaf.hash.
+func (m *MerkleMap) Proof(key string) (merkle.SimpleProof, []byte) {
+	idx := m.indexOf(key)
+	leaf := m.tree().leafAt(idx)
 
-	sm.kvs.Sort()
-	sm.sorted = true
+	proof := merkle.SimpleProof{
+		Total:    int64(len(m.keys)),
+		Index:    int64(idx),
+		LeafHash: leaf.hash,
+		Aunts:    m.root.aunts(idx),
+	}
+	return proof, leaf.leaf
 }
 
-// kvPair defines a type alias for kv.Pair so that we can create bytes to hash
-// when constructing the merkle root. Note, key and values are both length-prefixed.
-type kvPair kv.Pair
-
-// bytes returns a byte slice representation of the kvPair where the key and value
-// are length-prefixed.
-func (kv kvPair) bytes() []byte {
-	var b bytes.Buffer
+func (m *MerkleMap) indexOf(key string) int {
+	return sort.SearchStrings(m.keys, key)
+}
 
-	err := encodeByteSlice(&b, kv.Key)
-	if err != nil {
-		panic(err)
+// tree returns the cached tree, building it from the current leaves if it is
+// missing (first use, or a key was inserted since the last build).
+func (m *MerkleMap) tree() *merkleNode {
+	if m.root == nil {
+		leaves := make([][]byte, len(m.keys))
+		for i, k := range m.keys {
+			leaves[i] = kvPair{Key: []byte(k), Value: m.leaves[k]}.bytes()
+		}
+		m.root = buildMerkleNode(leaves, 0, len(leaves))
 	}
-
-	err = encodeByteSlice(&b, kv.Value)
-	if err != nil {
-		panic(err)
+	return m.root
+}
+
+// merkleNode is one node of the cached binary Merkle tree. Every node
+// covers the leaf range [start, end); leaf nodes additionally store the raw,
+// length-prefixed kv bytes they hash.
+type merkleNode struct {
+	hash        []byte
+	start, end  int
+	left, right *merkleNode
+	leaf        []byte
+}
+
+func buildMerkleNode(leaves [][]byte, start, end int) *merkleNode {
+	n := &merkleNode{start: start, end: end}
+
+	switch end - start {
+	case 0:
+		n.hash = emptyHash()
+	case 1:
+		n.leaf = leaves[start]
+		n.hash = leafHash(n.leaf)
+	default:
+		k := splitPoint(end - start)
+		n.left = buildMerkleNode(leaves, start, start+k)
+		n.right = buildMerkleNode(leaves, start+k, end)
+		n.hash = innerHash(n.left.hash, n.right.hash)
 	}
 
-	return b.Bytes()
+	return n
 }
 
-func encodeByteSlice(w io.Writer, bz []byte) error {
-	var buf [8]byte
-	n := binary.PutUvarint(buf[:], uint64(len(bz)))
-
-	_, err := w.Write(buf[:n])
-	if err != nil {
-		return err
+// update sets the leaf at idx to leafBz and recomputes the hash of every
+// ancestor on the path back to n, leaving every sibling subtree untouched.
+func (n *merkleNode) update(idx int, leafBz []byte) {
+	if n.left == nil {
+		n.leaf = leafBz
+		n.hash = leafHash(leafBz)
+		return
 	}
 
-	_, err = w.Write(bz)
-	return err
-}
-
-// hashKVPairs hashes a kvPair and creates a merkle tree where the leaves are
-// byte slices.
-func hashKVPairs(kvs kv.Pairs) []byte {
-	kvsH := make([][]byte, len(kvs))
-	for i, kvp := range kvs {
-		kvsH[i] = kvPair(kvp).bytes()
+	if idx < n.left.end {
+		n.left.update(idx, leafBz)
+	} else {
+		n.right.update(idx, leafBz)
 	}
 
-	return merkle.SimpleHashFromByteSlices(kvsH)
+	n.hash = innerHash(n.left.hash, n.right.hash)
 }
 
-// ---------------------------------------------
-
-// Merkle tree from a map.
-// Leaves are `hash(key) | hash(value)`.
-// Leaves are sorted before Merkle hashing.
-type simpleMap struct {
-	kvs    kv.Pairs
-	sorted bool
+// leafAt returns the leaf node covering idx.
+func (n *merkleNode) leafAt(idx int) *merkleNode {
+	if n.left == nil {
+		return n
+	}
+	if idx < n.left.end {
+		return n.left.leafAt(idx)
+	}
+	return n.right.leafAt(idx)
 }
 
-func newSimpleMap() *simpleMap {
-	return &simpleMap{
-		kvs:    nil,
-		sorted: false,
+// aunts collects the sibling hash at each level on the path from idx to n,
+// ordered leaf-to-root as required by merkle.SimpleProof.
+func (n *merkleNode) aunts(idx int) [][]byte {
+	if n.left == nil {
+		return nil
 	}
+	if idx < n.left.end {
+		return append(n.left.aunts(idx), n.right.hash)
+	}
+	return append(n.right.aunts(idx), n.left.hash)
 }
 
-// Set creates a kv pair of the key and the hash of the value,
-// and then appends it to simpleMap's kv pairs.
-func (sm *simpleMap) Set(key string, value []byte) {
-	sm.sorted = false
-
-	// The value is hashed, so you can
-	// check for equality with a cached value (say)
-	// and make a determination to fetch or not.
-	vhash := tmhash.Sum(value)
-
-	sm.kvs = append(sm.kvs, kv.Pair{
-		Key:   []byte(key),
-		Value: vhash,
-	})
+// splitPoint returns the largest power of two strictly less than length,
+// matching the split used by tmhash/merkle.SimpleHashFromByteSlices so that
+// MerkleMap produces the same root hash (and compatible proofs) as the
+// code it replaces.
+func splitPoint(length int) int {
+	if length < 1 {
+		panic("trying to split a tree with size < 1")
+	}
+	k := 1 << uint(bits.Len(uint(length))-1)
+	if k == length {
+		k >>= 1
+	}
+	return k
 }
 
-// Hash Merkle root hash of items sorted by key
-// (UNSTABLE: and by value too if duplicate key).
-func (sm *simpleMap) Hash() []byte {
-	sm.Sort()
-	return hashKVPairs(sm.kvs)
+// emptyHash is the root of a map with no keys: nil, as returned by
+// merkle.SimpleHashFromByteSlices for an empty slice.
+func emptyHash() []byte {
+	return nil
 }
 
-func (sm *simpleMap) Sort() {
-	if sm.sorted {
-		return
-	}
-	sm.kvs.Sort()
-	sm.sorted = true
+func leafHash(leaf []byte) []byte {
+	return tmhash.Sum(append([]byte{0}, leaf...))
 }
 
-// Returns a copy of sorted KVPairs.
-// NOTE these contain the hashed key and value.
-func (sm *simpleMap) KVPairs() kv.Pairs {
-	sm.Sort()
-	kvs := make(kv.Pairs, len(sm.kvs))
-	copy(kvs, sm.kvs)
-	return kvs
+func innerHash(left, right []byte) []byte {
+	data := append([]byte{1}, left...)
+	data = append(data, right...)
+	return tmhash.Sum(data)
 }
 
-//----------------------------------------
-
-// A local extension to KVPair that can be hashed.
-// Key and value are length prefixed and concatenated,
-// then hashed.
-type KVPair kv.Pair
-
-// NewKVPair takes in a key and value and creates a kv.Pair
-// wrapped in the local extension KVPair
-func NewKVPair(key, value []byte) KVPair {
-	return KVPair(kv.Pair{
-		Key:   key,
-		Value: value,
-	})
+// kvPair defines a key/value pair so that we can create
+// bytes to hash when constructing the merkle root. Note, key and values are
+// both length-prefixed.
+type kvPair struct {
+	Key   []byte
+	Value []byte
 }
 
-// Bytes returns key || value, with both the
-// key and value length prefixed.
-func (kv KVPair) Bytes() []byte {
+// bytes returns a byte slice representation of the kvPair where the key and
+// value are length-prefixed.
+func (kv kvPair) bytes() []byte {
 	var b bytes.Buffer
+
 	err := encodeByteSlice(&b, kv.Key)
 	if err != nil {
 		panic(err)
 	}
+
 	err = encodeByteSlice(&b, kv.Value)
 	if err != nil {
 		panic(err)
 	}
+
 	return b.Bytes()
 }
+
+func encodeByteSlice(w io.Writer, bz []byte) error {
+	var buf [8]byte
+	n := binary.PutUvarint(buf[:], uint64(len(bz)))
+
+	_, err := w.Write(buf[:n])
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(bz)
+	return err
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/x/evidence"
+	clientexported "github.com/cosmos/cosmos-sdk/x/ibc/02-client/exported"
+	"github.com/cosmos/cosmos-sdk/x/ibc/02-client/types"
+	tendermint "github.com/cosmos/cosmos-sdk/x/ibc/07-tendermint"
+	ibctmtypes "github.com/cosmos/cosmos-sdk/x/ibc/07-tendermint/types"
+)
+
+// AttributeValueCategory is the value of the module attribute emitted on
+// every event produced by this package, matching the convention used by the
+// sibling 03-connection handlers.
+const AttributeValueCategory = types.SubModuleName
+
+// HandleMsgSubmitClientMisbehaviour defines the sdk.Handler for
+// MsgSubmitClientMisbehaviour. It verifies double-sign evidence against a
+// counterparty light client (Tendermint or solo machine) through the
+// existing UpdateState machinery, freezing the client on success. Forwarding
+// to x/evidence is scoped to Tendermint misbehaviour only, mirroring the
+// provider-side equivocation handler this is modeled on: a solo machine's
+// "evidence" is just two signatures from one un-staked key, with no bonded
+// validator set and no voting power behind it, so there is no consensus
+// address or power to slash.
+func HandleMsgSubmitClientMisbehaviour(ctx sdk.Context, k Keeper, ek evidence.Keeper, msg *types.MsgSubmitClientMisbehaviour) (*sdk.Result, error) {
+	clientMsg, ok := msg.Misbehaviour.GetCachedValue().(clientexported.ClientMessage)
+	if !ok {
+		return nil, sdkerrors.Wrap(types.ErrInvalidClientType, "misbehaviour does not implement the ClientMessage interface")
+	}
+
+	misbehaviour, ok := clientMsg.(clientexported.Misbehaviour)
+	if !ok {
+		return nil, sdkerrors.Wrap(types.ErrInvalidClientType, "client message is not evidence of misbehaviour")
+	}
+
+	if err := k.UpdateState(ctx, misbehaviour.GetClientID(), clientMsg); err != nil {
+		return nil, sdkerrors.Wrap(err, "failed to verify client misbehaviour")
+	}
+
+	tmEvidence, ok := clientMsg.(ibctmtypes.Evidence)
+	if !ok {
+		// UpdateState above has already frozen the client; there is simply
+		// no bonded validator behind this client type to forward to
+		// x/evidence.
+		return &sdk.Result{
+			Events: ctx.EventManager().Events().ToABCIEvents(),
+		}, nil
+	}
+
+	consensusState, found := k.GetClientConsensusState(ctx, tmEvidence.GetClientID(), uint64(tmEvidence.GetHeight()))
+	if !found {
+		return nil, sdkerrors.Wrap(types.ErrInvalidEvidence, "consensus state trusted by the misbehaviour evidence no longer exists")
+	}
+
+	tmConsensusState, ok := consensusState.(ibctmtypes.ConsensusState)
+	if !ok {
+		return nil, sdkerrors.Wrap(types.ErrInvalidClientType, "consensus state trusted by the misbehaviour evidence is not Tendermint")
+	}
+
+	validator, err := tendermint.GetByzantineValidator(tmEvidence, tmConsensusState)
+	if err != nil {
+		return nil, sdkerrors.Wrap(err, "failed to identify byzantine validator from misbehaviour evidence")
+	}
+
+	consAddr := sdk.ConsAddress(validator.Address)
+
+	equivocation := evidence.Equivocation{
+		Height:           tmEvidence.GetHeight(),
+		Time:             ctx.BlockTime(),
+		Power:            validator.VotingPower,
+		ConsensusAddress: consAddr,
+	}
+
+	if err := ek.SubmitEvidence(ctx, &equivocation); err != nil {
+		return nil, sdkerrors.Wrap(err, "failed to submit client misbehaviour as evidence")
+	}
+
+	ctx.EventManager().EmitEvents(sdk.Events{
+		sdk.NewEvent(
+			types.EventTypeSubmitMisbehaviour,
+			sdk.NewAttribute(types.AttributeKeyClientID, misbehaviour.GetClientID()),
+			sdk.NewAttribute(types.AttributeKeyClientType, misbehaviour.ClientType().String()),
+			sdk.NewAttribute(types.AttributeKeyConsensusAddress, consAddr.String()),
+		),
+		sdk.NewEvent(
+			sdk.EventTypeMessage,
+			sdk.NewAttribute(sdk.AttributeKeyModule, AttributeValueCategory),
+		),
+	})
+
+	return &sdk.Result{
+		Events: ctx.EventManager().Events().ToABCIEvents(),
+	}, nil
+}
@@ -1,13 +1,81 @@
 package connection
 
 import (
+	"strings"
+
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
 	"github.com/cosmos/cosmos-sdk/x/ibc/03-connection/types"
 	commitmentexported "github.com/cosmos/cosmos-sdk/x/ibc/23-commitment/exported"
 )
 
+// localhostClientIDPrefix is the identifier prefix reserved for the
+// now-removed 09-localhost client type. Client identifiers are generated as
+// "<type>-<sequence>" (e.g. "09-localhost-0"), never the bare type string,
+// so matching must be a prefix check rather than an equality check against
+// the type string alone.
+const localhostClientIDPrefix = "09-localhost"
+
+// rejectLocalhostClientID returns an error if clientID refers to the removed
+// 09-localhost client.
+func rejectLocalhostClientID(clientID string) error {
+	if strings.HasPrefix(clientID, localhostClientIDPrefix) {
+		return sdkerrors.Wrapf(
+			types.ErrInvalidClientID,
+			"client ID %s is reserved for the removed 09-localhost client and can no longer be used to open a connection", clientID,
+		)
+	}
+	return nil
+}
+
+// handleSelfConnection replaces 09-localhost's sole use case: a chain
+// opening a connection to itself. Rather than maintaining a stateful
+// "localhost" client that has to be kept updated and can be (mis)frozen
+// like any other counterparty client, a self-connection is detected
+// directly from clientID's stored chain-id and short-circuited through
+// k.ConnOpenInitSelf, which operates on local state without a counterparty
+// proof. It reports whether msg.ClientID was self-referential, so the
+// caller knows whether it already completed the handshake step.
+//
+// NOTE: bounded to 03-connection, the only IBC handshake package present in
+// this checkout; the analogous dispatch point the request also calls for in
+// x/ibc/04-channel is out of scope here since that package isn't part of
+// this checkout.
+func handleSelfConnection(ctx sdk.Context, k Keeper, msg MsgConnectionOpenInit) (*sdk.Result, bool, error) {
+	if !k.IsSelfClient(ctx, msg.ClientID) {
+		return nil, false, nil
+	}
+
+	if err := k.ConnOpenInitSelf(ctx, msg.ConnectionID, msg.ClientID, msg.Counterparty); err != nil {
+		return nil, true, err
+	}
+
+	ctx.EventManager().EmitEvents(sdk.Events{
+		sdk.NewEvent(
+			types.EventTypeConnectionOpenInit,
+			sdk.NewAttribute(types.AttributeKeyConnectionID, msg.ConnectionID),
+			sdk.NewAttribute(types.AttributeKeyClientID, msg.ClientID),
+			sdk.NewAttribute(types.AttributeKeyCounterpartyClientID, msg.Counterparty.ClientID),
+		),
+		sdk.NewEvent(
+			sdk.EventTypeMessage,
+			sdk.NewAttribute(sdk.AttributeKeyModule, AttributeValueCategory),
+		),
+	})
+
+	return &sdk.Result{Events: ctx.EventManager().Events().ToABCIEvents()}, true, nil
+}
+
 // HandleMsgConnectionOpenInit defines the sdk.Handler for MsgConnectionOpenInit
 func HandleMsgConnectionOpenInit(ctx sdk.Context, k Keeper, msg MsgConnectionOpenInit) (*sdk.Result, error) {
+	if err := rejectLocalhostClientID(msg.ClientID); err != nil {
+		return nil, err
+	}
+
+	if result, handled, err := handleSelfConnection(ctx, k, msg); handled {
+		return result, err
+	}
+
 	if err := k.ConnOpenInit(
 		ctx, msg.ConnectionID, msg.ClientID, msg.Counterparty,
 	); err != nil {
@@ -34,6 +102,10 @@ func HandleMsgConnectionOpenInit(ctx sdk.Context, k Keeper, msg MsgConnectionOpe
 
 // HandleMsgConnectionOpenTry defines the sdk.Handler for MsgConnectionOpenTry
 func HandleMsgConnectionOpenTry(ctx sdk.Context, k Keeper, msg MsgConnectionOpenTry) (*sdk.Result, error) {
+	if err := rejectLocalhostClientID(msg.ClientID); err != nil {
+		return nil, err
+	}
+
 	proofInit := msg.ProofInit.GetCachedValue().(commitmentexported.Proof)
 	proofConsensus := msg.ProofConsensus.GetCachedValue().(commitmentexported.Proof)
 
@@ -0,0 +1,183 @@
+package types
+
+import (
+	"bytes"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	clientexported "github.com/cosmos/cosmos-sdk/x/ibc/02-client/exported"
+	clienttypes "github.com/cosmos/cosmos-sdk/x/ibc/02-client/types"
+	commitmentexported "github.com/cosmos/cosmos-sdk/x/ibc/23-commitment/exported"
+	commitmenttypes "github.com/cosmos/cosmos-sdk/x/ibc/23-commitment/types"
+	host "github.com/cosmos/cosmos-sdk/x/ibc/24-host"
+)
+
+// verifySignature checks that proof is a valid SignatureProof, signed by
+// clientState's current public key at clientState's current sequence, over
+// the sign bytes for (path, value). The proof is rejected unless its
+// Sequence matches clientState's current ConsensusState.Sequence exactly and
+// its Timestamp is not older than the stored ConsensusState.Timestamp: since
+// a solo machine only ever signs one message per sequence, gating on the
+// *current* sequence before verifying the signature is what stops a
+// previously-valid proof from being replayed, rather than merely bumping a
+// counter after the fact. On success it returns the ClientState with its
+// ConsensusState.Sequence incremented and ConsensusState.Timestamp advanced
+// to the proof's timestamp.
+func verifySignature(
+	clientState ClientState, path commitmentexported.Path, value []byte, proofBz []byte, isMembership bool,
+) (ClientState, error) {
+	var proof commitmenttypes.SignatureProof
+	if err := SubModuleCdc.UnmarshalBinaryBare(proofBz, &proof); err != nil {
+		return clientState, sdkerrors.Wrapf(clienttypes.ErrInvalidClientType, "failed to unmarshal solo machine proof: %v", err)
+	}
+
+	if proof.Sequence != clientState.ConsensusState.Sequence {
+		return clientState, sdkerrors.Wrapf(
+			commitmenttypes.ErrInvalidProof,
+			"proof sequence %d does not match the current consensus state sequence %d", proof.Sequence, clientState.ConsensusState.Sequence,
+		)
+	}
+
+	if proof.Timestamp < clientState.ConsensusState.Timestamp {
+		return clientState, sdkerrors.Wrapf(
+			commitmenttypes.ErrInvalidProof,
+			"proof timestamp %d is before the current consensus state timestamp %d", proof.Timestamp, clientState.ConsensusState.Timestamp,
+		)
+	}
+
+	root := commitmenttypes.NewPublicKeyRoot(*clientState.ConsensusState.PubKey)
+
+	var err error
+	if isMembership {
+		err = proof.VerifyMembership(root, path, value)
+	} else {
+		err = proof.VerifyNonMembership(root, path)
+	}
+	if err != nil {
+		return clientState, sdkerrors.Wrap(clienttypes.ErrInvalidEvidence, err.Error())
+	}
+
+	clientState.ConsensusState.Sequence++
+	clientState.ConsensusState.Timestamp = proof.Timestamp
+	return clientState, nil
+}
+
+// VerifyClientConsensusState verifies a proof of the consensus state of the
+// counterparty's client, recorded against this solo machine. On success the
+// returned ClientState has its sequence incremented so the proof cannot be
+// replayed against it.
+func (cs ClientState) VerifyClientConsensusState(
+	height uint64,
+	counterpartyClientIdentifier string,
+	consensusHeight uint64,
+	prefix commitmentexported.Prefix,
+	proof []byte,
+	consensusState clientexported.ConsensusState,
+) (ClientState, error) {
+	path := commitmenttypes.NewMerklePath(
+		[]string{string(prefix.Bytes()), clienttypes.FullClientStateConsensusStatePath(counterpartyClientIdentifier, consensusHeight)},
+	)
+
+	bz, err := SubModuleCdc.MarshalBinaryBare(consensusState)
+	if err != nil {
+		return cs, sdkerrors.Wrap(err, "failed to marshal consensus state")
+	}
+
+	return verifySignature(cs, path, bz, proof, true)
+}
+
+// VerifyPacketCommitment verifies a proof of an outgoing packet commitment at
+// the given sequence. On success the returned ClientState has its sequence
+// incremented so the proof cannot be replayed against it.
+func (cs ClientState) VerifyPacketCommitment(
+	height uint64,
+	proof []byte,
+	portID, channelID string,
+	packetSequence uint64,
+	commitmentBytes []byte,
+) (ClientState, error) {
+	path := commitmenttypes.NewMerklePath(
+		[]string{host.PacketCommitmentPath(portID, channelID, packetSequence)},
+	)
+
+	return verifySignature(cs, path, commitmentBytes, proof, true)
+}
+
+// VerifyPacketAcknowledgementAbsence verifies a proof that an acknowledgement
+// has not yet been written for the given packet sequence.
+func (cs ClientState) VerifyPacketAcknowledgementAbsence(
+	height uint64,
+	proof []byte,
+	portID, channelID string,
+	packetSequence uint64,
+) (ClientState, error) {
+	path := commitmenttypes.NewMerklePath(
+		[]string{host.PacketAcknowledgementPath(portID, channelID, packetSequence)},
+	)
+
+	return verifySignature(cs, path, nil, proof, false)
+}
+
+// UpdateState advances a solo machine client by dispatching on the concrete
+// type of clientMsg: a Header rotates the client onto a new public key at
+// the next sequence, while Evidence of two conflicting signatures at the
+// same sequence freezes the client. This is the sole entry point for both,
+// replacing the former separate checkHeaderAndUpdateState/
+// checkMisbehaviourAndUpdateState call sites.
+func (cs ClientState) UpdateState(clientMsg clientexported.ClientMessage) (ClientState, error) {
+	switch msg := clientMsg.(type) {
+	case Evidence:
+		return checkMisbehaviourAndUpdateState(cs, msg)
+	case Header:
+		return checkHeaderAndUpdateState(cs, msg)
+	default:
+		return cs, sdkerrors.Wrapf(clienttypes.ErrInvalidClientType, "unsupported ClientMessage type %T", clientMsg)
+	}
+}
+
+// checkHeaderAndUpdateState verifies that header was signed, at the client's
+// current sequence, by the private key corresponding to the client's current
+// public key, then rotates the client onto header's new public key.
+func checkHeaderAndUpdateState(clientState ClientState, header Header) (ClientState, error) {
+	signBytes := append(sdk.Uint64ToBigEndian(clientState.ConsensusState.Sequence), header.NewPubKey.Bytes()...)
+
+	if !clientState.ConsensusState.PubKey.VerifySignature(signBytes, header.Signature) {
+		return clientState, sdkerrors.Wrap(clienttypes.ErrInvalidHeader, "header signature does not match the trusted public key")
+	}
+
+	clientState.ConsensusState.PubKey = header.NewPubKey
+	clientState.ConsensusState.Sequence++
+	return clientState, nil
+}
+
+// checkMisbehaviourAndUpdateState verifies that evidence contains two
+// distinct messages, both signed by the same public key at the same
+// sequence. Since a solo machine should only ever sign one message per
+// sequence, two valid signatures over different data proves the machine's
+// key was used to equivocate, and the client is frozen.
+func checkMisbehaviourAndUpdateState(clientState ClientState, evidence Evidence) (ClientState, error) {
+	if clientState.IsFrozen() {
+		return clientState, sdkerrors.Wrap(clienttypes.ErrInvalidEvidence, "client is already frozen")
+	}
+
+	if bytes.Equal(evidence.SignatureOne.Data, evidence.SignatureTwo.Data) {
+		return clientState, sdkerrors.Wrap(clienttypes.ErrInvalidEvidence, "evidence signatures are over the same data")
+	}
+
+	pubKey := clientState.ConsensusState.PubKey
+	sequence := evidence.Sequence
+
+	sigOneBytes := append(sdk.Uint64ToBigEndian(sequence), evidence.SignatureOne.Data...)
+	sigTwoBytes := append(sdk.Uint64ToBigEndian(sequence), evidence.SignatureTwo.Data...)
+
+	if !pubKey.VerifySignature(sigOneBytes, evidence.SignatureOne.Signature) {
+		return clientState, sdkerrors.Wrap(clienttypes.ErrInvalidEvidence, "failed to verify signature one")
+	}
+
+	if !pubKey.VerifySignature(sigTwoBytes, evidence.SignatureTwo.Signature) {
+		return clientState, sdkerrors.Wrap(clienttypes.ErrInvalidEvidence, "failed to verify signature two")
+	}
+
+	clientState.FrozenSequence = sequence
+	return clientState, nil
+}
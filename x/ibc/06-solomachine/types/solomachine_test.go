@@ -17,6 +17,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	clientexported "github.com/cosmos/cosmos-sdk/x/ibc/02-client/exported"
 	solomachinetypes "github.com/cosmos/cosmos-sdk/x/ibc/06-solomachine/types"
+	commitmenttypes "github.com/cosmos/cosmos-sdk/x/ibc/23-commitment/types"
 	host "github.com/cosmos/cosmos-sdk/x/ibc/24-host"
 )
 
@@ -130,4 +131,94 @@ func (suite *SoloMachineTestSuite) GetSequenceFromStore() uint64 {
 	var clientState solomachinetypes.ClientState
 	suite.aminoCdc.MustUnmarshalBinaryBare(bz, &clientState)
 	return clientState.ConsensusState.Sequence
-}
\ No newline at end of file
+}
+
+// signedPacketCommitmentProof signs and marshals a SignatureProof over the
+// packet commitment path at the given sequence/timestamp, exactly as a
+// solo machine counterparty would when proving a packet commitment.
+func (suite *SoloMachineTestSuite) signedPacketCommitmentProof(sequence, timestamp uint64, commitmentBytes []byte) []byte {
+	path := commitmenttypes.NewMerklePath([]string{host.PacketCommitmentPath("portidone", "channelidone", 1)})
+	signBytes := commitmenttypes.SignBytes(sequence, timestamp, path, commitmentBytes)
+
+	signature, err := suite.privKey.Sign(signBytes)
+	suite.Require().NoError(err)
+
+	proof := commitmenttypes.SignatureProof{
+		Sequence:  sequence,
+		Timestamp: timestamp,
+		Signature: signature,
+	}
+	return solomachinetypes.SubModuleCdc.MustMarshalBinaryBare(proof)
+}
+
+// TestVerifyPacketCommitmentReplayProtection asserts that a SignatureProof
+// accepted once cannot be replayed: VerifyPacketCommitment must consume the
+// sequence it was signed at, so resubmitting the exact same proof bytes
+// against the resulting ClientState fails.
+func (suite *SoloMachineTestSuite) TestVerifyPacketCommitmentReplayProtection() {
+	clientState := suite.ClientState()
+	commitmentBytes := []byte("packet commitment bytes")
+	proofBz := suite.signedPacketCommitmentProof(suite.sequence, suite.timestamp, commitmentBytes)
+
+	updated, err := clientState.VerifyPacketCommitment(0, proofBz, "portidone", "channelidone", 1, commitmentBytes)
+	suite.Require().NoError(err)
+	suite.Require().Equal(suite.sequence+1, updated.ConsensusState.Sequence)
+
+	_, err = updated.VerifyPacketCommitment(0, proofBz, "portidone", "channelidone", 1, commitmentBytes)
+	suite.Require().Error(err, "replaying a proof signed at a since-consumed sequence must be rejected")
+}
+
+// TestVerifyPacketCommitmentSequenceMismatch asserts that a proof signed at
+// a sequence other than the client's current ConsensusState.Sequence is
+// rejected outright, before its signature is even checked.
+func (suite *SoloMachineTestSuite) TestVerifyPacketCommitmentSequenceMismatch() {
+	clientState := suite.ClientState()
+	commitmentBytes := []byte("packet commitment bytes")
+	proofBz := suite.signedPacketCommitmentProof(suite.sequence+1, suite.timestamp, commitmentBytes)
+
+	_, err := clientState.VerifyPacketCommitment(0, proofBz, "portidone", "channelidone", 1, commitmentBytes)
+	suite.Require().Error(err)
+}
+
+// TestVerifyPacketCommitmentStaleTimestamp asserts that a proof timestamped
+// before the client's stored ConsensusState.Timestamp is rejected, even
+// though its sequence matches.
+func (suite *SoloMachineTestSuite) TestVerifyPacketCommitmentStaleTimestamp() {
+	clientState := suite.ClientState()
+	commitmentBytes := []byte("packet commitment bytes")
+	staleTimestamp := suite.timestamp - 1
+	proofBz := suite.signedPacketCommitmentProof(suite.sequence, staleTimestamp, commitmentBytes)
+
+	_, err := clientState.VerifyPacketCommitment(0, proofBz, "portidone", "channelidone", 1, commitmentBytes)
+	suite.Require().Error(err)
+}
+
+// signedPacketAckAbsenceProof signs and marshals a SignatureProof of the
+// absence of an acknowledgement at the given sequence/timestamp, exactly as
+// a solo machine counterparty would when proving non-membership.
+func (suite *SoloMachineTestSuite) signedPacketAckAbsenceProof(sequence, timestamp uint64) []byte {
+	path := commitmenttypes.NewMerklePath([]string{host.PacketAcknowledgementPath("portidone", "channelidone", 1)})
+	signBytes := commitmenttypes.SignBytes(sequence, timestamp, path, nil)
+
+	signature, err := suite.privKey.Sign(signBytes)
+	suite.Require().NoError(err)
+
+	proof := commitmenttypes.SignatureProof{
+		Sequence:  sequence,
+		Timestamp: timestamp,
+		Signature: signature,
+	}
+	return solomachinetypes.SubModuleCdc.MustMarshalBinaryBare(proof)
+}
+
+// TestVerifyPacketAcknowledgementAbsence asserts the non-membership path:
+// a proof of absence signed at the client's current sequence verifies and
+// consumes that sequence, just like the membership paths.
+func (suite *SoloMachineTestSuite) TestVerifyPacketAcknowledgementAbsence() {
+	clientState := suite.ClientState()
+	proofBz := suite.signedPacketAckAbsenceProof(suite.sequence, suite.timestamp)
+
+	updated, err := clientState.VerifyPacketAcknowledgementAbsence(0, proofBz, "portidone", "channelidone", 1)
+	suite.Require().NoError(err)
+	suite.Require().Equal(suite.sequence+1, updated.ConsensusState.Sequence)
+}
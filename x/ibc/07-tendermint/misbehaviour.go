@@ -11,15 +11,43 @@ import (
 	"github.com/cosmos/cosmos-sdk/x/ibc/07-tendermint/types"
 )
 
-// CheckMisbehaviourAndUpdateState determines whether or not two conflicting
-// headers at the same height would have convinced the light client.
+// UpdateState advances a Tendermint client by dispatching on the concrete
+// type of clientMsg: Evidence of two conflicting signed headers at the same
+// height is checked by checkMisbehaviourAndUpdateState and freezes the
+// client, while a Header continues through the existing
+// CheckHeaderAndUpdateState path. This is the sole entry point for both,
+// replacing the former separate CheckHeaderAndUpdateState/
+// CheckMisbehaviourAndUpdateState call sites.
 //
 // NOTE: assumes provided height is the height at which the consensusState is
 // stored.
-func CheckMisbehaviourAndUpdateState(
+func UpdateState(
+	clientState clientexported.ClientState,
+	consensusState clientexported.ConsensusState,
+	clientMsg clientexported.ClientMessage,
+	height uint64,
+	currentTimestamp time.Time,
+) (clientexported.ClientState, error) {
+	switch msg := clientMsg.(type) {
+	case types.Evidence:
+		return checkMisbehaviourAndUpdateState(clientState, consensusState, msg, height, currentTimestamp)
+	case types.Header:
+		// header-triggered updates continue to be handled by the existing
+		// CheckHeaderAndUpdateState path; it already accepts a concrete
+		// Header and is unaffected by the Misbehaviour/Evidence dispatch
+		// added here.
+		return CheckHeaderAndUpdateState(clientState, msg, currentTimestamp)
+	default:
+		return nil, sdkerrors.Wrapf(clienttypes.ErrInvalidClientType, "unsupported ClientMessage type %T", clientMsg)
+	}
+}
+
+// checkMisbehaviourAndUpdateState determines whether or not two conflicting
+// headers at the same height would have convinced the light client.
+func checkMisbehaviourAndUpdateState(
 	clientState clientexported.ClientState,
 	consensusState clientexported.ConsensusState,
-	misbehaviour clientexported.Misbehaviour,
+	evidence types.Evidence,
 	height uint64, // height at which the consensus state was loaded
 	currentTimestamp time.Time,
 ) (clientexported.ClientState, error) {
@@ -30,10 +58,14 @@ func CheckMisbehaviourAndUpdateState(
 		return nil, sdkerrors.Wrap(clienttypes.ErrInvalidClientType, "client state type is not Tendermint")
 	}
 
-	// If client is already frozen at earlier height than evidence, return with error
-	if tmClientState.IsFrozen() && tmClientState.FrozenHeight <= uint64(misbehaviour.GetHeight()) {
+	// If client is already frozen at an earlier or the same height as the
+	// evidence, there is nothing more freezing at this height would
+	// accomplish. A client frozen at a *later* height may still be frozen
+	// again at an earlier misbehaviour height, since that earlier freezing
+	// height is the one ultimately recorded.
+	if tmClientState.IsFrozen() && tmClientState.FrozenHeight <= uint64(evidence.GetHeight()) {
 		return nil, sdkerrors.Wrapf(clienttypes.ErrInvalidEvidence,
-			"client is already frozen at earlier height %d than misbehaviour height %d", tmClientState.FrozenHeight, misbehaviour.GetHeight())
+			"client is already frozen at height %d, at or before misbehaviour height %d", tmClientState.FrozenHeight, evidence.GetHeight())
 	}
 
 	tmConsensusState, ok := consensusState.(types.ConsensusState)
@@ -41,18 +73,13 @@ func CheckMisbehaviourAndUpdateState(
 		return nil, sdkerrors.Wrap(clienttypes.ErrInvalidClientType, "consensus state is not Tendermint")
 	}
 
-	tmEvidence, ok := misbehaviour.(types.Evidence)
-	if !ok {
-		return nil, sdkerrors.Wrap(clienttypes.ErrInvalidClientType, "evidence type is not Tendermint")
-	}
-
 	if err := checkMisbehaviour(
-		tmClientState, tmConsensusState, tmEvidence, height, currentTimestamp,
+		tmClientState, tmConsensusState, evidence, height, currentTimestamp,
 	); err != nil {
 		return nil, sdkerrors.Wrap(clienttypes.ErrInvalidEvidence, err.Error())
 	}
 
-	tmClientState.FrozenHeight = uint64(tmEvidence.GetHeight())
+	tmClientState.FrozenHeight = uint64(evidence.GetHeight())
 	return tmClientState, nil
 }
 
@@ -96,8 +123,33 @@ func checkMisbehaviour(
 		return sdkerrors.Wrapf(clienttypes.ErrInvalidEvidence, "invalid signed header 2: %s", err.Error())
 	}
 
-	// TODO: Evidence must be within trusting period
-	// Blocked on https://github.com/cosmos/ics/issues/379
+	// The consensus state evidence is checked against must itself still be
+	// within the trusting period, otherwise the validator set it recorded
+	// may no longer be bonded.
+	if err := checkTrustingPeriod(consensusState.Timestamp, currentTimestamp, clientState.TrustingPeriod); err != nil {
+		return err
+	}
+
+	// the evidence's own headers must also be within the trusting period: the
+	// earlier of their two timestamps is checked.
+	earliestHeaderTime := evidence.Header1.GetTime()
+	if header2Time := evidence.Header2.GetTime(); header2Time.Before(earliestHeaderTime) {
+		earliestHeaderTime = header2Time
+	}
+	if currentTimestamp.Sub(earliestHeaderTime) >= clientState.TrustingPeriod {
+		return sdkerrors.Wrapf(
+			types.ErrTrustingPeriodExpired,
+			"current timestamp minus the earliest header timestamp is greater than or equal to the trusting period (%s >= %s)",
+			currentTimestamp.Sub(earliestHeaderTime), clientState.TrustingPeriod,
+		)
+	}
+
+	// the headers must not claim timestamps that diverge from one another
+	// by more than the configured max clock drift, otherwise a validator
+	// could use a header far in the future to forge misbehaviour.
+	if err := checkMaxClockDrift(evidence.Header1.GetTime(), evidence.Header2.GetTime(), clientState.MaxClockDrift); err != nil {
+		return err
+	}
 
 	// - ValidatorSet must have (1-trustLevel) similarity with trusted FromValidatorSet
 	// - ValidatorSets on both headers are valid given the last trusted ValidatorSet
@@ -117,3 +169,84 @@ func checkMisbehaviour(
 
 	return nil
 }
+
+// checkTrustingPeriod rejects evidence whose trusted consensusTimestamp is
+// older than currentTimestamp - trustingPeriod. The validator set recorded
+// by that consensus state is what the conflicting headers are verified
+// against; once it falls outside the trusting period it may no longer be
+// bonded, so it can no longer be trusted to prove misbehaviour.
+func checkTrustingPeriod(consensusTimestamp, currentTimestamp time.Time, trustingPeriod time.Duration) error {
+	if currentTimestamp.Sub(consensusTimestamp) >= trustingPeriod {
+		return sdkerrors.Wrapf(
+			types.ErrTrustingPeriodExpired,
+			"current timestamp minus the consensus state timestamp is greater than or equal to the trusting period (%s >= %s)",
+			currentTimestamp.Sub(consensusTimestamp), trustingPeriod,
+		)
+	}
+
+	return nil
+}
+
+// checkMaxClockDrift rejects a pair of header timestamps that diverge from
+// one another by more than maxClockDrift. Two honestly produced headers at
+// the same height should never disagree on their timestamp by more than the
+// network's configured clock drift tolerance.
+func checkMaxClockDrift(header1Timestamp, header2Timestamp time.Time, maxClockDrift time.Duration) error {
+	drift := header1Timestamp.Sub(header2Timestamp)
+	if drift < 0 {
+		drift = -drift
+	}
+
+	if drift > maxClockDrift {
+		return sdkerrors.Wrapf(
+			clienttypes.ErrInvalidEvidence,
+			"header timestamps diverge by more than the max clock drift (%s > %s)", drift, maxClockDrift,
+		)
+	}
+
+	return nil
+}
+
+// GetByzantineValidator returns the bonded validator responsible for
+// evidence: the first signer of evidence's first conflicting header that
+// also signed the second one and is in the validator set trusted by
+// consensusState is returned, since its two signatures are the proof that a
+// bonded validator attested to two different headers at the same height. It
+// is exported for callers (such as the 02-client
+// MsgSubmitClientMisbehaviour handler) that need to slash and jail the
+// offending validator after UpdateState has frozen the client.
+func GetByzantineValidator(evidence types.Evidence, consensusState types.ConsensusState) (*tmtypes.Validator, error) {
+	valset, err := tmtypes.ValidatorSetFromProto(consensusState.ValidatorSet)
+	if err != nil {
+		return nil, sdkerrors.Wrap(clienttypes.ErrInvalidEvidence, err.Error())
+	}
+
+	signedHeader1, err := tmtypes.SignedHeaderFromProto(&evidence.Header1.SignedHeader)
+	if err != nil {
+		return nil, sdkerrors.Wrapf(clienttypes.ErrInvalidEvidence, "invalid signed header 1: %s", err.Error())
+	}
+
+	signedHeader2, err := tmtypes.SignedHeaderFromProto(&evidence.Header2.SignedHeader)
+	if err != nil {
+		return nil, sdkerrors.Wrapf(clienttypes.ErrInvalidEvidence, "invalid signed header 2: %s", err.Error())
+	}
+
+	signedHeader2By := make(map[string]bool)
+	for _, sig := range signedHeader2.Commit.Signatures {
+		if !sig.Absent() {
+			signedHeader2By[string(sig.ValidatorAddress)] = true
+		}
+	}
+
+	for _, sig := range signedHeader1.Commit.Signatures {
+		if sig.Absent() || !signedHeader2By[string(sig.ValidatorAddress)] {
+			continue
+		}
+
+		if _, validator := valset.GetByAddress(sig.ValidatorAddress); validator != nil {
+			return validator, nil
+		}
+	}
+
+	return nil, sdkerrors.Wrap(clienttypes.ErrInvalidEvidence, "could not find a bonded validator that signed both conflicting headers")
+}
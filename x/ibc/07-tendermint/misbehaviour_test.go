@@ -0,0 +1,96 @@
+package tendermint
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTrustingPeriod(t *testing.T) {
+	const trustingPeriod = time.Hour
+	consensusTimestamp := time.Unix(0, 0)
+
+	testCases := []struct {
+		name      string
+		current   time.Time
+		expExpire bool
+	}{
+		{
+			"just under the trusting period: not expired",
+			consensusTimestamp.Add(trustingPeriod - time.Nanosecond),
+			false,
+		},
+		{
+			"exactly at the trusting period boundary: expired",
+			consensusTimestamp.Add(trustingPeriod),
+			true,
+		},
+		{
+			"past the trusting period: expired",
+			consensusTimestamp.Add(trustingPeriod + time.Nanosecond),
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkTrustingPeriod(consensusTimestamp, tc.current, trustingPeriod)
+			if tc.expExpire {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckMaxClockDrift(t *testing.T) {
+	const maxClockDrift = time.Minute
+	base := time.Unix(0, 0)
+
+	testCases := []struct {
+		name     string
+		header1  time.Time
+		header2  time.Time
+		expError bool
+	}{
+		{
+			"identical timestamps: no drift",
+			base,
+			base,
+			false,
+		},
+		{
+			"exactly at the max clock drift boundary: allowed",
+			base,
+			base.Add(maxClockDrift),
+			false,
+		},
+		{
+			"one nanosecond past the max clock drift: rejected",
+			base,
+			base.Add(maxClockDrift + time.Nanosecond),
+			true,
+		},
+		{
+			"drift in the other direction is measured the same way",
+			base.Add(maxClockDrift + time.Nanosecond),
+			base,
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkMaxClockDrift(tc.header1, tc.header2, maxClockDrift)
+			if tc.expError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package types
+
+import (
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+)
+
+// ErrTrustingPeriodExpired is returned when misbehaviour evidence is too old
+// to trust: if the earliest of its two header timestamps is more than the
+// client's trusting period before the current block time, the validator set
+// the evidence was checked against may no longer be bonded.
+var ErrTrustingPeriodExpired = sdkerrors.Register("tendermint", 31, "time since latest trusted state has passed the trusting period")
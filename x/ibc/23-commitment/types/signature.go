@@ -2,15 +2,65 @@ package types
 
 import (
 	cdctypes "github.com/cosmos/cosmos-sdk/codec/types"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
 	"github.com/cosmos/cosmos-sdk/x/ibc/23-commitment/exported"
 )
 
+// absenceMarker is appended to the sign bytes of a non-membership proof in
+// place of the (absent) value, so that a non-membership proof for a path is
+// distinct from a membership proof of any value at that path other than nil
+// or absenceMarker itself.
+var absenceMarker = []byte("solomachine absence")
+
 var (
 	_ exported.Proof  = (*SignatureProof)(nil)
 	_ exported.Prefix = (*SignaturePrefix)(nil)
+	_ exported.Root   = (*PublicKeyRoot)(nil)
 )
 
+// PublicKeyRoot wraps the public key a SignatureProof must be verified
+// against. Unlike the Merkle roots used by 07-tendermint, a solo machine has
+// no state tree to commit to, so its "root" is simply the current public key
+// held by the counterparty's ConsensusState.
+type PublicKeyRoot struct {
+	PubKey cryptotypes.PublicKey
+}
+
+// NewPublicKeyRoot creates a new PublicKeyRoot instance.
+func NewPublicKeyRoot(pubKey cryptotypes.PublicKey) PublicKeyRoot {
+	return PublicKeyRoot{PubKey: pubKey}
+}
+
+// GetHash implements exported.Root.
+func (r PublicKeyRoot) GetHash() []byte {
+	return r.PubKey.Bytes()
+}
+
+// Empty implements exported.Root.
+func (r PublicKeyRoot) Empty() bool {
+	return len(r.PubKey.Bytes()) == 0
+}
+
+// SignBytes returns the canonical bytes a solo machine must sign over in
+// order to prove membership (or non-membership) of value at path, at the
+// given sequence and timestamp. Non-membership proofs pass a nil value; the
+// absenceMarker is substituted for any nil value, so the resulting sign bytes
+// are the same as those of a membership proof of absenceMarker (or of a nil
+// value) over the same path, and distinct from any other membership proof.
+func SignBytes(sequence, timestamp uint64, path exported.Path, value []byte) []byte {
+	if value == nil {
+		value = absenceMarker
+	}
+
+	bz := sdk.Uint64ToBigEndian(sequence)
+	bz = append(bz, sdk.Uint64ToBigEndian(timestamp)...)
+	bz = append(bz, []byte(path.String())...)
+	bz = append(bz, value...)
+	return bz
+}
+
 // NewSignaturePrefix constructs a new SignaturePrefix instance.
 func NewSignaturePrefix(keyPrefix []byte) SignaturePrefix {
 	return SignaturePrefix{
@@ -47,13 +97,47 @@ func (SignatureProof) GetCommitmentType() exported.Type {
 	return exported.Signature
 }
 
-// VerifyMembership implements ProofI.
-func (SignatureProof) VerifyMembership(exported.Root, exported.Path, []byte) error {
+// VerifyMembership implements ProofI. It verifies that the proof's signature
+// was produced, at the proof's sequence and timestamp, by the private key
+// corresponding to root's public key, over the canonical sign bytes for
+// (path, value).
+func (proof SignatureProof) VerifyMembership(root exported.Root, path exported.Path, value []byte) error {
+	if err := proof.ValidateBasic(); err != nil {
+		return err
+	}
+
+	pubKeyRoot, ok := root.(PublicKeyRoot)
+	if !ok {
+		return sdkerrors.Wrapf(ErrInvalidProof, "expected root to be solo machine PublicKeyRoot, got %T", root)
+	}
+
+	signBytes := SignBytes(proof.Sequence, proof.Timestamp, path, value)
+	if !pubKeyRoot.PubKey.VerifySignature(signBytes, proof.Signature) {
+		return sdkerrors.Wrap(ErrInvalidProof, "failed to verify membership proof signature")
+	}
+
 	return nil
 }
 
-// VerifyNonMembership implements ProofI.
-func (SignatureProof) VerifyNonMembership(exported.Root, exported.Path) error {
+// VerifyNonMembership implements ProofI. It verifies that the proof's
+// signature was produced, at the proof's sequence and timestamp, by the
+// private key corresponding to root's public key, over the canonical sign
+// bytes attesting to the absence of a value at path.
+func (proof SignatureProof) VerifyNonMembership(root exported.Root, path exported.Path) error {
+	if err := proof.ValidateBasic(); err != nil {
+		return err
+	}
+
+	pubKeyRoot, ok := root.(PublicKeyRoot)
+	if !ok {
+		return sdkerrors.Wrapf(ErrInvalidProof, "expected root to be solo machine PublicKeyRoot, got %T", root)
+	}
+
+	signBytes := SignBytes(proof.Sequence, proof.Timestamp, path, nil)
+	if !pubKeyRoot.PubKey.VerifySignature(signBytes, proof.Signature) {
+		return sdkerrors.Wrap(ErrInvalidProof, "failed to verify non-membership proof signature")
+	}
+
 	return nil
 }
 